@@ -1,30 +1,66 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"text/template"
+	"time"
 
+	"snippetbox.consdotpy.xyz/internal/api"
+	"snippetbox.consdotpy.xyz/internal/mailer"
+	"snippetbox.consdotpy.xyz/internal/migrations"
 	"snippetbox.consdotpy.xyz/internal/models"
+	"snippetbox.consdotpy.xyz/internal/ratelimit"
+	"snippetbox.consdotpy.xyz/internal/reaper"
 
+	"github.com/alexedwards/scs/mysqlstore"
+	"github.com/alexedwards/scs/v2"
 	_ "github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type configuration struct {
-	addr      string
-	staticDir string
-	dsn       string
+	addr            string
+	staticDir       string
+	dsn             string
+	migrate         string
+	migrateSteps    int
+	bcryptCost      int
+	smtp            mailer.SMTPConfig
+	reapInterval    time.Duration
+	shutdownTimeout time.Duration
+	rateRPS         float64
+	rateBurst       int
+	rateAuthRPS     float64
+	trustedProxies  string
 }
 
 type application struct {
-	errorLog      *log.Logger
-	infoLog       *log.Logger
-	config        configuration
-	snippets      *models.SnippetModel
-	templateCache map[string]*template.Template
+	errorLog       *log.Logger
+	infoLog        *log.Logger
+	config         configuration
+	snippets       *models.SnippetModel
+	users          *models.UserModel
+	tokens         *models.TokenModel
+	templateCache  map[string]*template.Template
+	sessionManager *scs.SessionManager
+	mailer         mailer.Sender
+	api            *api.API
+	reaper         *reaper.Reaper
+	generalLimiter *ratelimit.Limiter
+	authLimiter    *ratelimit.Limiter
+	trustedProxies []*net.IPNet
+	wg             sync.WaitGroup
 }
 
 func openDB(dsn string) (*sql.DB, error) {
@@ -38,12 +74,64 @@ func openDB(dsn string) (*sql.DB, error) {
 	return db, nil
 }
 
+// runMigrationsAndExit applies, reports the status of, or repairs the
+// database schema migrations and then terminates the process, so that
+// "-migrate" is always a one-shot operation rather than a prelude to
+// serving requests.
+func runMigrationsAndExit(db *sql.DB, infoLog, errorLog *log.Logger, mode string, steps int) {
+	switch mode {
+	case "up":
+		if err := migrations.Migrate(db, migrations.Up, steps); err != nil {
+			errorLog.Fatal(err)
+		}
+		infoLog.Print("Migrations applied")
+	case "down":
+		if err := migrations.Migrate(db, migrations.Down, steps); err != nil {
+			errorLog.Fatal(err)
+		}
+		infoLog.Print("Migrations reverted")
+	case "status":
+		applied, pending, err := migrations.Status(db)
+		if err != nil {
+			errorLog.Fatal(err)
+		}
+		infoLog.Printf("Applied migrations: %v", applied)
+		infoLog.Printf("Pending migrations: %v", pending)
+	case "force":
+		if steps == 0 {
+			errorLog.Fatal("-migrate=force requires -migrate-steps=<version> to mark resolved")
+		}
+		if err := migrations.ForceVersion(db, steps); err != nil {
+			errorLog.Fatal(err)
+		}
+		infoLog.Printf("Marked version %d resolved", steps)
+	default:
+		errorLog.Fatalf("unknown -migrate value %q, want up, down, status or force", mode)
+	}
+
+	os.Exit(0)
+}
+
 // main is the application's entry point.
 func main() {
 	var config configuration
 	flag.StringVar(&config.addr, "addr", ":4000", "HTTP network address")
 	flag.StringVar(&config.staticDir, "static-dir", "./ui/static/", "Path to static assets")
 	flag.StringVar(&config.dsn, "dsn", "", "MySQL data source name")
+	flag.StringVar(&config.migrate, "migrate", "", "Run database migrations: up, down, status or force (with -migrate-steps=<version>, to clear a dirty migration)")
+	flag.IntVar(&config.migrateSteps, "migrate-steps", 0, "Target migration version for -migrate=up/down (0 means all)")
+	flag.IntVar(&config.bcryptCost, "bcrypt-cost", bcrypt.DefaultCost, "bcrypt work factor for password hashing")
+	flag.StringVar(&config.smtp.Host, "smtp-host", "", "SMTP host (unset uses the dev logging mailer)")
+	flag.IntVar(&config.smtp.Port, "smtp-port", 25, "SMTP port")
+	flag.StringVar(&config.smtp.Username, "smtp-username", "", "SMTP username")
+	flag.StringVar(&config.smtp.Password, "smtp-password", "", "SMTP password")
+	flag.StringVar(&config.smtp.Sender, "smtp-sender", "Snippetbox <no-reply@snippetbox.consdotpy.xyz>", "SMTP \"From\" address")
+	flag.DurationVar(&config.reapInterval, "reap-interval", 10*time.Minute, "How often to delete expired snippets")
+	flag.DurationVar(&config.shutdownTimeout, "shutdown-timeout", 10*time.Second, "Grace period for in-flight requests during shutdown")
+	flag.Float64Var(&config.rateRPS, "rate-rps", 2, "Requests per second allowed per client")
+	flag.IntVar(&config.rateBurst, "rate-burst", 4, "Burst size allowed per client")
+	flag.Float64Var(&config.rateAuthRPS, "rate-auth-rps", 0.2, "Requests per second allowed per client on auth endpoints")
+	flag.StringVar(&config.trustedProxies, "trusted-proxies", "", "Comma-separated CIDR ranges trusted to set X-Forwarded-For")
 	flag.Parse()
 
 	infoLog := log.New(
@@ -64,6 +152,10 @@ func main() {
 
 	defer db.Close()
 
+	if config.migrate != "" {
+		runMigrationsAndExit(db, infoLog, errorLog, config.migrate, config.migrateSteps)
+	}
+
 	snippets, err := models.NewSnippetModel(db)
 	if err != nil {
 		errorLog.Fatal(err)
@@ -78,12 +170,47 @@ func main() {
 		errorLog.Fatal(err)
 	}
 
+	sessionManager := scs.New()
+	sessionManager.Store = mysqlstore.New(db)
+	sessionManager.Lifetime = 12 * time.Hour
+
+	var mailSender mailer.Sender
+	if config.smtp.Host == "" {
+		mailSender = mailer.NewDevSender(infoLog)
+	} else {
+		mailSender = mailer.NewSMTPSender(config.smtp)
+	}
+
+	users := models.NewUserModel(db, config.bcryptCost)
+	authTokens := models.NewAuthTokenModel(db)
+
+	var trustedProxyCIDRs []string
+	if config.trustedProxies != "" {
+		trustedProxyCIDRs = strings.Split(config.trustedProxies, ",")
+	}
+	trustedProxies, err := ratelimit.ParseTrustedProxies(trustedProxyCIDRs)
+	if err != nil {
+		errorLog.Fatal(err)
+	}
+
+	generalLimiter := ratelimit.New(config.rateRPS, config.rateBurst)
+	authLimiter := ratelimit.New(config.rateAuthRPS, config.rateBurst)
+
 	app := &application{
-		errorLog:      errorLog,
-		infoLog:       infoLog,
-		config:        config,
-		snippets:      snippets,
-		templateCache: templateCache,
+		errorLog:       errorLog,
+		infoLog:        infoLog,
+		config:         config,
+		snippets:       snippets,
+		users:          users,
+		tokens:         models.NewTokenModel(db),
+		templateCache:  templateCache,
+		sessionManager: sessionManager,
+		mailer:         mailSender,
+		api:            api.New(errorLog, snippets, users, authTokens, generalLimiter, authLimiter, trustedProxies),
+		reaper:         reaper.New(db, config.reapInterval, errorLog),
+		generalLimiter: generalLimiter,
+		authLimiter:    authLimiter,
+		trustedProxies: trustedProxies,
 	}
 
 	srv := &http.Server{
@@ -92,10 +219,53 @@ func main() {
 		Handler:  app.routes(),
 	}
 
-	// Start server.
-	infoLog.Printf("Starting server on %s", config.addr)
-	err = srv.ListenAndServe()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app.wg.Add(1)
+	go func() {
+		defer app.wg.Done()
+		app.reaper.Run(ctx)
+	}()
+
+	evictorDone := make(chan struct{})
+	app.wg.Add(2)
+	go func() {
+		defer app.wg.Done()
+		app.generalLimiter.StartEvictor(evictorDone, time.Minute, 3*time.Minute)
+	}()
+	go func() {
+		defer app.wg.Done()
+		app.authLimiter.StartEvictor(evictorDone, time.Minute, 3*time.Minute)
+	}()
+	go func() {
+		<-ctx.Done()
+		close(evictorDone)
+	}()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		infoLog.Printf("Starting server on %s", config.addr)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errorLog.Fatal(err)
+		}
+	case <-ctx.Done():
+		infoLog.Print("Shutting down server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			errorLog.Print(err)
+		}
+	}
+
+	app.wg.Wait()
 
-	// Log and exit on server start error.
-	errorLog.Fatal(err)
+	infoLog.Print("Stopped server")
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"snippetbox.consdotpy.xyz/internal/ratelimit"
+)
+
+// requireAuthentication redirects anonymous users to the login page before
+// they reach a handler that needs an authenticated user.
+func (app *application) requireAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.sessionManager.GetInt(r.Context(), "authenticatedUserID") == 0 {
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKey buckets authenticated requests by user id and everyone else
+// by client IP, so one heavy logged-in user can't exhaust the bucket shared
+// by anonymous visitors behind the same NAT.
+func (app *application) rateLimitKey(r *http.Request) string {
+	if userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID"); userID != 0 {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return "ip:" + ratelimit.ClientIP(r, app.trustedProxies)
+}
+
+// generalRateLimit applies the default per-client rate limit to a handler.
+func (app *application) generalRateLimit(next http.Handler) http.Handler {
+	return ratelimit.Middleware(app.generalLimiter, app.rateLimitKey)(next)
+}
+
+// authRateLimit applies a stricter rate limit, meant for login/signup/reset
+// endpoints, to blunt credential stuffing.
+func (app *application) authRateLimit(next http.Handler) http.Handler {
+	return ratelimit.Middleware(app.authLimiter, app.rateLimitKey)(next)
+}
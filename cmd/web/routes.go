@@ -2,21 +2,62 @@ package main
 
 import (
 	"net/http"
+
+	"github.com/julienschmidt/httprouter"
 )
 
-func (app *application) routes() *http.ServeMux {
-	// Create a new ServeMux.
-	mux := http.NewServeMux()
+func (app *application) routes() http.Handler {
+	router := httprouter.New()
+
+	router.NotFound = http.HandlerFunc(app.notFoundHandler)
 
 	// Serve static files from "./ui/static/" directory.
 	fileServer := http.FileServer(http.Dir(app.config.staticDir))
-	mux.Handle("/static", http.NotFoundHandler())
-	mux.Handle("/static/", http.StripPrefix("/static", fileServer))
+	router.Handler(http.MethodGet, "/static/*filepath", http.StripPrefix("/static", fileServer))
+
+	router.HandlerFunc(http.MethodGet, "/ping", ping)
+
+	dynamic := app.sessionManager.LoadAndSave
+
+	// public applies the default rate limit; auth applies the stricter one
+	// used for credential-guessing-prone endpoints. Both run after dynamic
+	// so handlers can key the limiter off the session.
+	public := func(h http.HandlerFunc) http.Handler {
+		return dynamic(app.generalRateLimit(h))
+	}
+	auth := func(h http.HandlerFunc) http.Handler {
+		return dynamic(app.authRateLimit(h))
+	}
+	protected := func(h http.HandlerFunc) http.Handler {
+		return dynamic(app.generalRateLimit(app.requireAuthentication(h)))
+	}
+
+	router.Handler(http.MethodGet, "/", public(app.home))
+	router.Handler(http.MethodGet, "/snippet/view/:id", public(app.snippetView))
+	router.Handler(http.MethodGet, "/user/signup", public(app.userSignup))
+	router.Handler(http.MethodPost, "/user/signup", auth(app.userSignupPost))
+	router.Handler(http.MethodGet, "/user/login", public(app.userLogin))
+	router.Handler(http.MethodPost, "/user/login", auth(app.userLoginPost))
+	router.Handler(http.MethodGet, "/user/verify", public(app.userVerify))
+	router.Handler(http.MethodGet, "/user/forgot", public(app.userForgotPassword))
+	router.Handler(http.MethodPost, "/user/forgot", auth(app.userForgotPasswordPost))
+	router.Handler(http.MethodGet, "/user/reset", public(app.userResetPassword))
+	router.Handler(http.MethodPost, "/user/reset", auth(app.userResetPasswordPost))
+
+	router.Handler(http.MethodGet, "/snippet/create", protected(app.snippetCreate))
+	router.Handler(http.MethodPost, "/snippet/create", protected(app.snippetCreatePost))
+	router.Handler(http.MethodGet, "/snippet/mine", protected(app.snippetsMine))
+	router.Handler(http.MethodGet, "/snippet/edit/:id", protected(app.snippetEdit))
+	router.Handler(http.MethodPost, "/snippet/edit/:id", protected(app.snippetEditPost))
+	router.Handler(http.MethodPost, "/snippet/delete/:id", protected(app.snippetDeletePost))
+	router.Handler(http.MethodPost, "/user/logout", protected(app.userLogoutPost))
+	router.Handler(http.MethodGet, "/debug/reaper", protected(app.debugReaper))
+
+	app.api.Register(router)
 
-	// Register handler functions for URL patterns.
-	mux.HandleFunc("/", app.home)
-	mux.HandleFunc("/snippet/view", app.snippetView)
-	mux.HandleFunc("/snippet/create", app.snippetCreate)
+	return router
+}
 
-	return mux
-}
\ No newline at end of file
+func (app *application) notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	app.notFound(w)
+}
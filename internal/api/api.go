@@ -0,0 +1,35 @@
+// Package api exposes the JSON REST surface under /api/v1/..., backed by the
+// same internal/models layer as the HTML handlers in cmd/web.
+package api
+
+import (
+	"log"
+	"net"
+
+	"snippetbox.consdotpy.xyz/internal/models"
+	"snippetbox.consdotpy.xyz/internal/ratelimit"
+)
+
+// API holds the dependencies shared by every JSON handler.
+type API struct {
+	ErrorLog       *log.Logger
+	Snippets       *models.SnippetModel
+	Users          *models.UserModel
+	AuthTokens     *models.TokenModel
+	GeneralLimiter *ratelimit.Limiter
+	AuthLimiter    *ratelimit.Limiter
+	TrustedProxies []*net.IPNet
+}
+
+// New returns an API ready to have its routes registered.
+func New(errorLog *log.Logger, snippets *models.SnippetModel, users *models.UserModel, authTokens *models.TokenModel, generalLimiter, authLimiter *ratelimit.Limiter, trustedProxies []*net.IPNet) *API {
+	return &API{
+		ErrorLog:       errorLog,
+		Snippets:       snippets,
+		Users:          users,
+		AuthTokens:     authTokens,
+		GeneralLimiter: generalLimiter,
+		AuthLimiter:    authLimiter,
+		TrustedProxies: trustedProxies,
+	}
+}
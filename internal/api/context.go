@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"snippetbox.consdotpy.xyz/internal/models"
+)
+
+type contextKey string
+
+const userContextKey = contextKey("user")
+
+func contextSetUser(r *http.Request, user *models.User) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userContextKey, user))
+}
+
+// contextGetUser returns the user attached to the request by Authenticate,
+// or nil for an anonymous request.
+func contextGetUser(r *http.Request) *models.User {
+	user, ok := r.Context().Value(userContextKey).(*models.User)
+	if !ok {
+		return nil
+	}
+	return user
+}
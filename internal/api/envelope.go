@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Envelope wraps every JSON response body in a named top-level key (e.g.
+// {"snippet": {...}}), which keeps the wire format extensible without
+// breaking clients that decode into a struct.
+type Envelope map[string]any
+
+// writeJSON marshals data as JSON, sets any extra headers, and writes status
+// to w.
+func writeJSON(w http.ResponseWriter, status int, data Envelope, headers http.Header) error {
+	body, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	for key, values := range headers {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func (a *API) logError(r *http.Request, err error) {
+	a.ErrorLog.Printf("%s %s: %v", r.Method, r.URL.RequestURI(), err)
+}
+
+// errorResponse writes a {"error": message} envelope. message may be a
+// string or, for validation failures, a map of field name to error message.
+func (a *API) errorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
+	err := writeJSON(w, status, Envelope{"error": message}, nil)
+	if err != nil {
+		a.logError(r, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (a *API) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	a.logError(r, err)
+	a.errorResponse(w, r, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+}
+
+func (a *API) notFoundResponse(w http.ResponseWriter, r *http.Request) {
+	a.errorResponse(w, r, http.StatusNotFound, "the requested resource could not be found")
+}
+
+func (a *API) methodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
+	a.errorResponse(w, r, http.StatusMethodNotAllowed, fmt.Sprintf("the %s method is not supported for this resource", r.Method))
+}
+
+func (a *API) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
+	a.errorResponse(w, r, http.StatusBadRequest, err.Error())
+}
+
+func (a *API) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
+	a.errorResponse(w, r, http.StatusUnprocessableEntity, errors)
+}
+
+func (a *API) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
+	a.errorResponse(w, r, http.StatusForbidden, "you do not have permission to act on this resource")
+}
+
+func (a *API) invalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
+	a.errorResponse(w, r, http.StatusUnauthorized, "invalid authentication credentials")
+}
+
+func (a *API) inactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
+	a.errorResponse(w, r, http.StatusUnauthorized, "your account must be verified before you can authenticate")
+}
+
+func (a *API) invalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	a.errorResponse(w, r, http.StatusUnauthorized, "invalid or missing authentication token")
+}
+
+func (a *API) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	a.errorResponse(w, r, http.StatusUnauthorized, "you must be authenticated to access this resource")
+}
+
+func (a *API) notAcceptableResponse(w http.ResponseWriter, r *http.Request) {
+	a.errorResponse(w, r, http.StatusNotAcceptable, "this endpoint only supports the application/json media type")
+}
+
+func (a *API) unsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request) {
+	a.errorResponse(w, r, http.StatusUnsupportedMediaType, "the request body must have the Content-Type application/json")
+}
+
+func (a *API) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+	}
+	a.errorResponse(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+}
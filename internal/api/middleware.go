@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"snippetbox.consdotpy.xyz/internal/models"
+	"snippetbox.consdotpy.xyz/internal/ratelimit"
+)
+
+// Authenticate reads a "Authorization: Bearer <token>" header, if present,
+// and attaches the corresponding user to the request context. A missing
+// header leaves the request anonymous; a malformed or invalid one is
+// rejected outright so a typo'd token can't silently fall back to anonymous.
+func (a *API) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+
+		authorizationHeader := r.Header.Get("Authorization")
+		if authorizationHeader == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		parts := strings.Split(authorizationHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			a.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		user, err := a.AuthTokens.GetUserForToken(models.ScopeAuthentication, parts[1])
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				a.invalidAuthenticationTokenResponse(w, r)
+			} else {
+				a.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, contextSetUser(r, &user))
+	})
+}
+
+// RequireAuthenticatedUser rejects requests that Authenticate didn't attach
+// a user to.
+func (a *API) RequireAuthenticatedUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contextGetUser(r) == nil {
+			a.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAcceptsJSON enforces content negotiation on the response: clients
+// that can't accept JSON get a 406 rather than JSON they didn't ask for.
+func (a *API) RequireAcceptsJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header.Get("Accept")
+		if accept != "" && accept != "*/*" && !strings.Contains(accept, "application/json") {
+			a.notAcceptableResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RateLimit applies limiter's per-client rate limit, keyed by the
+// authenticated user if Authenticate has already attached one to the
+// request, falling back to client IP for anonymous requests.
+func (a *API) RateLimit(limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, retryAfter := limiter.Allow(a.rateLimitKey(r))
+			if !ok {
+				a.rateLimitExceededResponse(w, r, retryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (a *API) rateLimitKey(r *http.Request) string {
+	if user := contextGetUser(r); user != nil {
+		return fmt.Sprintf("user:%d", user.ID)
+	}
+	return "ip:" + ratelimit.ClientIP(r, a.TrustedProxies)
+}
+
+// requireJSONContentType rejects non-empty request bodies whose Content-Type
+// is set to something other than application/json, used by handlers that
+// read a JSON body. A body sent without a Content-Type header is accepted.
+func (a *API) requireJSONContentType(w http.ResponseWriter, r *http.Request) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+		a.unsupportedMediaTypeResponse(w, r)
+		return false
+	}
+
+	return true
+}
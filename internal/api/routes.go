@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Register wires every /api/v1/... route onto router, so it shares a single
+// httprouter instance (and its 404/405 handling) with the HTML routes.
+func (a *API) Register(router *httprouter.Router) {
+	generalRateLimit := a.RateLimit(a.GeneralLimiter)
+	authRateLimit := a.RateLimit(a.AuthLimiter)
+
+	negotiated := func(h http.HandlerFunc) http.Handler {
+		return a.Authenticate(a.RequireAcceptsJSON(generalRateLimit(http.HandlerFunc(h))))
+	}
+	authenticated := func(h http.HandlerFunc) http.Handler {
+		return a.Authenticate(a.RequireAcceptsJSON(generalRateLimit(a.RequireAuthenticatedUser(http.HandlerFunc(h)))))
+	}
+	// authNegotiated applies the stricter auth rate limit, used for the
+	// credential-guessing-prone token endpoint, same as cmd/web's auth
+	// routes.
+	authNegotiated := func(h http.HandlerFunc) http.Handler {
+		return a.Authenticate(a.RequireAcceptsJSON(authRateLimit(http.HandlerFunc(h))))
+	}
+
+	router.Handler(http.MethodPost, "/api/v1/tokens/authentication", authNegotiated(a.CreateAuthenticationToken))
+
+	router.Handler(http.MethodGet, "/api/v1/snippets", negotiated(a.ListSnippets))
+	router.Handler(http.MethodGet, "/api/v1/snippets/:id", negotiated(a.ShowSnippet))
+	router.Handler(http.MethodPost, "/api/v1/snippets", authenticated(a.CreateSnippet))
+	router.Handler(http.MethodPut, "/api/v1/snippets/:id", authenticated(a.UpdateSnippet))
+	router.Handler(http.MethodDelete, "/api/v1/snippets/:id", authenticated(a.DeleteSnippet))
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"snippetbox.consdotpy.xyz/internal/models"
+	"snippetbox.consdotpy.xyz/internal/validator"
+)
+
+type snippetInput struct {
+	Title               string `json:"title"`
+	Content             string `json:"content"`
+	Expires             int    `json:"expires"`
+	validator.Validator `json:"-"`
+}
+
+func snippetJSON(s models.Snippet) Envelope {
+	return Envelope{
+		"id":       s.ID,
+		"title":    s.Title,
+		"content":  s.Content,
+		"created":  s.Created,
+		"expires":  s.Expires,
+		"owner_id": s.OwnerID,
+	}
+}
+
+// ListSnippets handles "GET /api/v1/snippets", returning the ten most
+// recently created, unexpired snippets.
+func (a *API) ListSnippets(w http.ResponseWriter, r *http.Request) {
+	snippets, err := a.Snippets.Latest()
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	envelopes := make([]Envelope, len(snippets))
+	for i, s := range snippets {
+		envelopes[i] = snippetJSON(s)
+	}
+
+	writeJSON(w, http.StatusOK, Envelope{"snippets": envelopes}, nil)
+}
+
+// ShowSnippet handles "GET /api/v1/snippets/:id".
+func (a *API) ShowSnippet(w http.ResponseWriter, r *http.Request) {
+	id, err := idParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	snippet, err := a.Snippets.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			a.notFoundResponse(w, r)
+		} else {
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Envelope{"snippet": snippetJSON(snippet)}, nil)
+}
+
+// CreateSnippet handles "POST /api/v1/snippets". The caller must be
+// authenticated; the new snippet is owned by them.
+func (a *API) CreateSnippet(w http.ResponseWriter, r *http.Request) {
+	if !a.requireJSONContentType(w, r) {
+		return
+	}
+
+	var input snippetInput
+
+	err := readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	input.CheckField(validator.NotBlank(input.Title), "title", "This field cannot be blank")
+	input.CheckField(validator.MaxRunes(input.Title, 100), "title", "This field cannot be more than 100 characters long")
+	input.CheckField(validator.NotBlank(input.Content), "content", "This field cannot be blank")
+	input.CheckField(validator.AllowedValue(input.Expires, 1, 7, 365), "expires", "This field must equal 1, 7 or 365")
+
+	if !input.Valid() {
+		a.failedValidationResponse(w, r, input.FieldErrors)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	id, err := a.Snippets.Insert(input.Title, input.Content, input.Expires, user.ID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	snippet, err := a.Snippets.Get(id)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := http.Header{}
+	headers.Set("Location", "/api/v1/snippets/"+strconv.Itoa(id))
+
+	writeJSON(w, http.StatusCreated, Envelope{"snippet": snippetJSON(snippet)}, headers)
+}
+
+// UpdateSnippet handles "PUT /api/v1/snippets/:id". Only the owning user may
+// update a snippet.
+func (a *API) UpdateSnippet(w http.ResponseWriter, r *http.Request) {
+	if !a.requireJSONContentType(w, r) {
+		return
+	}
+
+	id, err := idParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	snippet, err := a.Snippets.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			a.notFoundResponse(w, r)
+		} else {
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user := contextGetUser(r)
+	if snippet.OwnerID != user.ID {
+		a.notPermittedResponse(w, r)
+		return
+	}
+
+	var input snippetInput
+
+	err = readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	input.CheckField(validator.NotBlank(input.Title), "title", "This field cannot be blank")
+	input.CheckField(validator.MaxRunes(input.Title, 100), "title", "This field cannot be more than 100 characters long")
+	input.CheckField(validator.NotBlank(input.Content), "content", "This field cannot be blank")
+	input.CheckField(validator.AllowedValue(input.Expires, 1, 7, 365), "expires", "This field must equal 1, 7 or 365")
+
+	if !input.Valid() {
+		a.failedValidationResponse(w, r, input.FieldErrors)
+		return
+	}
+
+	err = a.Snippets.Update(id, input.Title, input.Content, input.Expires)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	updated, err := a.Snippets.Get(id)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Envelope{"snippet": snippetJSON(updated)}, nil)
+}
+
+// DeleteSnippet handles "DELETE /api/v1/snippets/:id". Only the owning user
+// may delete a snippet.
+func (a *API) DeleteSnippet(w http.ResponseWriter, r *http.Request) {
+	id, err := idParam(r)
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	snippet, err := a.Snippets.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			a.notFoundResponse(w, r)
+		} else {
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user := contextGetUser(r)
+	if snippet.OwnerID != user.ID {
+		a.notPermittedResponse(w, r)
+		return
+	}
+
+	err = a.Snippets.Delete(id)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Envelope{"message": "snippet successfully deleted"}, nil)
+}
+
+func idParam(r *http.Request) (int, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid id parameter")
+	}
+
+	return id, nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"snippetbox.consdotpy.xyz/internal/models"
+	"snippetbox.consdotpy.xyz/internal/validator"
+)
+
+const authTokenTTL = 24 * time.Hour
+
+type createAuthTokenInput struct {
+	Email               string `json:"email"`
+	Password            string `json:"password"`
+	validator.Validator `json:"-"`
+}
+
+// CreateAuthenticationToken handles
+// "POST /api/v1/tokens/authentication", exchanging an email/password pair
+// for a bearer token that CLI or mobile clients attach as
+// "Authorization: Bearer <token>" on subsequent requests.
+func (a *API) CreateAuthenticationToken(w http.ResponseWriter, r *http.Request) {
+	if !a.requireJSONContentType(w, r) {
+		return
+	}
+
+	var input createAuthTokenInput
+
+	err := readJSON(w, r, &input)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	input.CheckField(validator.NotBlank(input.Email), "email", "This field cannot be blank")
+	input.CheckField(validator.Matches(input.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	input.CheckField(validator.NotBlank(input.Password), "password", "This field cannot be blank")
+
+	if !input.Valid() {
+		a.failedValidationResponse(w, r, input.FieldErrors)
+		return
+	}
+
+	userID, err := a.Users.Authenticate(input.Email, input.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			a.invalidCredentialsResponse(w, r)
+		} else {
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	verified, err := a.Users.IsVerified(userID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !verified {
+		a.inactiveAccountResponse(w, r)
+		return
+	}
+
+	token, err := a.AuthTokens.New(userID, authTokenTTL, models.ScopeAuthentication)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, Envelope{
+		"authentication_token": Envelope{
+			"token":  token.Plaintext,
+			"expiry": token.Expiry,
+		},
+	}, nil)
+}
@@ -0,0 +1,76 @@
+// Package mailer sends transactional emails such as verification links and
+// password-reset links.
+package mailer
+
+import (
+	"fmt"
+	"log"
+
+	"gopkg.in/mail.v2"
+)
+
+// Message is a single outgoing email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers Messages. The dev backend just logs them; the SMTP backend
+// sends them for real.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// SMTPConfig holds the settings needed to dial an SMTP relay.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Sender   string
+}
+
+// SMTPSender sends mail through an SMTP relay.
+type SMTPSender struct {
+	dialer *mail.Dialer
+	from   string
+}
+
+// NewSMTPSender returns a Sender that delivers mail through cfg.
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	dialer := mail.NewDialer(cfg.Host, cfg.Port, cfg.Username, cfg.Password)
+
+	return &SMTPSender{
+		dialer: dialer,
+		from:   cfg.Sender,
+	}
+}
+
+// Send delivers msg over SMTP.
+func (s *SMTPSender) Send(msg Message) error {
+	m := mail.NewMessage()
+	m.SetHeader("From", s.from)
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetBody("text/plain", msg.Body)
+
+	return s.dialer.DialAndSend(m)
+}
+
+// DevSender is a no-op Sender that logs messages instead of delivering them,
+// for use in local development when no SMTP relay is configured.
+type DevSender struct {
+	InfoLog *log.Logger
+}
+
+// NewDevSender returns a Sender that logs messages to infoLog.
+func NewDevSender(infoLog *log.Logger) *DevSender {
+	return &DevSender{InfoLog: infoLog}
+}
+
+// Send logs msg instead of sending it.
+func (s *DevSender) Send(msg Message) error {
+	s.InfoLog.Print(fmt.Sprintf("mailer: would send to %s, subject %q\n%s", msg.To, msg.Subject, msg.Body))
+	return nil
+}
@@ -0,0 +1,379 @@
+// Package migrations applies and tracks versioned SQL schema migrations.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// Direction selects whether Migrate applies or reverts migrations.
+type Direction int
+
+const (
+	Up Direction = iota
+	Down
+)
+
+// advisoryLockName is passed to MySQL's GET_LOCK/RELEASE_LOCK so that only
+// one process at a time can run migrations against a given database.
+const advisoryLockName = "snippetbox_migrations"
+
+type migration struct {
+	version int
+	name    string
+	upSQL   string
+	downSQL string
+}
+
+// dbConn is satisfied by both *sql.DB and *sql.Conn, so helpers shared by
+// the advisory-locked section (which passes its *sql.Conn) and by Status
+// (which passes the *sql.DB pool) can accept either.
+type dbConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Migrate brings the database to target by applying (dir == Up) or reverting
+// (dir == Down) migrations. A target of 0 with dir == Up applies every
+// pending migration; a target of 0 with dir == Down reverts everything.
+func Migrate(db *sql.DB, dir Direction, target int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// GET_LOCK/RELEASE_LOCK are scoped to the MySQL session that acquired
+	// them, so every statement in the locked section below must run on the
+	// same physical connection, not the shared *sql.DB pool (which is free
+	// to hand different calls different connections).
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	locked, err := acquireAdvisoryLock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return errors.New("migrations: could not acquire advisory lock, another process may be migrating")
+	}
+	defer releaseAdvisoryLock(ctx, conn)
+
+	if err := ensureSchemaMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+
+	if v, dirty, err := dirtyVersion(ctx, conn); err != nil {
+		return err
+	} else if dirty {
+		return fmt.Errorf("migrations: version %d is dirty (a previous run failed partway through applying it, and MySQL's DDL statements are not rolled back); inspect the schema by hand, repair it if needed, then run -migrate=force -migrate-steps=%d to mark it resolved", v, v)
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	pending := pendingMigrations(migrations, applied, dir, target)
+
+	for _, m := range pending {
+		if err := applyMigration(ctx, conn, m, dir); err != nil {
+			return fmt.Errorf("migrations: applying version %d: %w", m.version, err)
+		}
+	}
+
+	return nil
+}
+
+// Status returns the versions that have already been applied and the
+// versions that are still pending, both sorted ascending.
+func Status(db *sql.DB) (applied []int, pending []int, err error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := context.Background()
+
+	if err := ensureSchemaMigrationsTable(ctx, db); err != nil {
+		return nil, nil, err
+	}
+
+	applied, err = appliedVersions(ctx, db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appliedSet := make(map[int]bool, len(applied))
+	for _, v := range applied {
+		appliedSet[v] = true
+	}
+
+	for _, m := range migrations {
+		if !appliedSet[m.version] {
+			pending = append(pending, m.version)
+		}
+	}
+
+	return applied, pending, nil
+}
+
+func pendingMigrations(migrations []migration, applied []int, dir Direction, target int) []migration {
+	appliedSet := make(map[int]bool, len(applied))
+	for _, v := range applied {
+		appliedSet[v] = true
+	}
+
+	var pending []migration
+
+	if dir == Up {
+		for _, m := range migrations {
+			if appliedSet[m.version] {
+				continue
+			}
+			if target != 0 && m.version > target {
+				continue
+			}
+			pending = append(pending, m)
+		}
+		return pending
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if !appliedSet[m.version] {
+			continue
+		}
+		if m.version <= target {
+			continue
+		}
+		pending = append(pending, m)
+	}
+	return pending
+}
+
+// applyMigration runs a single migration's statements against db.
+//
+// MySQL implicitly commits CREATE/ALTER/DROP statements as it executes
+// them, so wrapping them in a sql.Tx would not make them atomic with each
+// other or with the bookkeeping below. Instead, the bookkeeping row is
+// written as "dirty" before any DDL runs and cleared only once every
+// statement has succeeded, so a failure partway through leaves a record of
+// which version needs manual attention rather than silently retrying DDL
+// that has already partly applied. See dirtyVersion and ForceVersion.
+func applyMigration(ctx context.Context, db dbConn, m migration, dir Direction) error {
+	stmt := m.upSQL
+	if dir == Down {
+		stmt = m.downSQL
+	}
+
+	if dir == Up {
+		if _, err := db.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, TRUE)`, m.version, m.name); err != nil {
+			return err
+		}
+	} else {
+		if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET dirty = TRUE WHERE version = ?`, m.version); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range splitStatements(stmt) {
+		if _, err := db.ExecContext(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	if dir == Up {
+		if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET dirty = FALSE WHERE version = ?`, m.version); err != nil {
+			return err
+		}
+	} else {
+		if _, err := db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ensureSchemaMigrationsTable(ctx context.Context, db dbConn) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version     INTEGER PRIMARY KEY,
+		name        VARCHAR(255) NOT NULL,
+		dirty       BOOLEAN NOT NULL DEFAULT FALSE,
+		applied_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+func appliedVersions(ctx context.Context, db dbConn) ([]int, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations WHERE dirty = FALSE ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+// dirtyVersion returns the version of a migration that failed partway
+// through applying, if any, so Migrate can refuse to proceed until an
+// operator has resolved it by hand.
+func dirtyVersion(ctx context.Context, db dbConn) (int, bool, error) {
+	var v int
+	err := db.QueryRowContext(ctx, `SELECT version FROM schema_migrations WHERE dirty = TRUE LIMIT 1`).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return v, true, nil
+}
+
+// ForceVersion marks version as cleanly applied without running any of its
+// SQL. It's the repair step for the error Migrate returns when it finds a
+// dirty version: after an operator has inspected the database and fixed up
+// (or confirmed) the schema by hand, this clears the dirty flag so Migrate
+// will resume normal operation.
+func ForceVersion(db *sql.DB, version int) error {
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	locked, err := acquireAdvisoryLock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return errors.New("migrations: could not acquire advisory lock, another process may be migrating")
+	}
+	defer releaseAdvisoryLock(ctx, conn)
+
+	if err := ensureSchemaMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	var name string
+	for _, m := range migrations {
+		if m.version == version {
+			name = m.name
+			break
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("migrations: no migration with version %d", version)
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, FALSE)
+		ON DUPLICATE KEY UPDATE dirty = FALSE`, version, name)
+	return err
+}
+
+func acquireAdvisoryLock(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var acquired int
+	err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, 10)`, advisoryLockName).Scan(&acquired)
+	return acquired == 1, err
+}
+
+func releaseAdvisoryLock(ctx context.Context, conn *sql.Conn) {
+	conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, advisoryLockName)
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		version, rest, ok := strings.Cut(name, "_")
+		if !ok {
+			continue
+		}
+
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, fmt.Errorf("migrations: invalid filename %q", name)
+		}
+
+		content, err := migrationFS.ReadFile("migrations/" + name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[v]
+		if !exists {
+			m = &migration{version: v}
+			byVersion[v] = m
+		}
+
+		switch {
+		case strings.HasSuffix(rest, ".up.sql"):
+			m.name = strings.TrimSuffix(rest, ".up.sql")
+			m.upSQL = string(content)
+		case strings.HasSuffix(rest, ".down.sql"):
+			m.downSQL = string(content)
+		default:
+			return nil, fmt.Errorf("migrations: invalid filename %q", name)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	return migrations, nil
+}
+
+func splitStatements(script string) []string {
+	var statements []string
+	for _, s := range strings.Split(script, ";") {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
@@ -0,0 +1,21 @@
+package models
+
+import "errors"
+
+var (
+	// ErrNoRecord is returned when a snippet or other record cannot be found.
+	ErrNoRecord = errors.New("models: no matching record found")
+
+	// ErrInvalidCredentials is returned when a user attempts to log in with an
+	// incorrect email address or password.
+	ErrInvalidCredentials = errors.New("models: invalid credentials")
+
+	// ErrDuplicateEmail is returned when a user tries to sign up with an email
+	// address that already exists in the database.
+	ErrDuplicateEmail = errors.New("models: duplicate email")
+
+	// ErrPermissionDenied describes an attempt to act on a record owned by
+	// someone else. No method in this package returns it: SnippetModel's
+	// Update and Delete do not check ownership.
+	ErrPermissionDenied = errors.New("models: permission denied")
+)
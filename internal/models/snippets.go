@@ -0,0 +1,165 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Snippet represents a single row of the snippets table.
+type Snippet struct {
+	ID      int
+	Title   string
+	Content string
+	Created time.Time
+	Expires time.Time
+	OwnerID int
+}
+
+// SnippetModel wraps a database connection pool and the prepared statements
+// used to query the snippets table.
+type SnippetModel struct {
+	DB *sql.DB
+
+	InsertStmt *sql.Stmt
+	GetStmt    *sql.Stmt
+	LatestStmt *sql.Stmt
+}
+
+// NewSnippetModel returns a SnippetModel with its prepared statements ready
+// for use.
+func NewSnippetModel(db *sql.DB) (*SnippetModel, error) {
+	insertStmt, err := db.Prepare(`INSERT INTO snippets (title, content, created, expires, owner_id)
+		VALUES (?, ?, UTC_TIMESTAMP(), DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? DAY), ?)`)
+	if err != nil {
+		return nil, err
+	}
+
+	getStmt, err := db.Prepare(`SELECT id, title, content, created, expires, owner_id FROM snippets
+		WHERE expires > UTC_TIMESTAMP() AND id = ?`)
+	if err != nil {
+		return nil, err
+	}
+
+	latestStmt, err := db.Prepare(`SELECT id, title, content, created, expires, owner_id FROM snippets
+		WHERE expires > UTC_TIMESTAMP() ORDER BY id DESC LIMIT 10`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SnippetModel{
+		DB:         db,
+		InsertStmt: insertStmt,
+		GetStmt:    getStmt,
+		LatestStmt: latestStmt,
+	}, nil
+}
+
+// Insert adds a new snippet owned by ownerID to the database and returns its id.
+func (m *SnippetModel) Insert(title string, content string, expires int, ownerID int) (int, error) {
+	result, err := m.InsertStmt.Exec(title, content, expires, ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Get returns a snippet by id, regardless of who owns it, as long as it has
+// not expired. Callers that need to enforce ownership should compare the
+// returned snippet's OwnerID against the authenticated user.
+func (m *SnippetModel) Get(id int) (Snippet, error) {
+	var s Snippet
+
+	err := m.GetStmt.QueryRow(id).Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires, &s.OwnerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Snippet{}, ErrNoRecord
+		}
+		return Snippet{}, err
+	}
+
+	return s, nil
+}
+
+// Latest returns the ten most recently created snippets that have not expired.
+func (m *SnippetModel) Latest() ([]Snippet, error) {
+	rows, err := m.LatestStmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snippets []Snippet
+
+	for rows.Next() {
+		var s Snippet
+
+		err = rows.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires, &s.OwnerID)
+		if err != nil {
+			return nil, err
+		}
+
+		snippets = append(snippets, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
+
+// Update overwrites the title, content and expiry of an existing snippet.
+// Callers are responsible for checking ownership before calling Update.
+func (m *SnippetModel) Update(id int, title, content string, expires int) error {
+	stmt := `UPDATE snippets
+		SET title = ?, content = ?, expires = DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? DAY)
+		WHERE id = ?`
+
+	_, err := m.DB.Exec(stmt, title, content, expires, id)
+	return err
+}
+
+// Delete removes a snippet. Callers are responsible for checking ownership
+// before calling Delete.
+func (m *SnippetModel) Delete(id int) error {
+	_, err := m.DB.Exec(`DELETE FROM snippets WHERE id = ?`, id)
+	return err
+}
+
+// UserSnippets returns every unexpired snippet owned by userID, most recent first.
+func (m *SnippetModel) UserSnippets(userID int) ([]Snippet, error) {
+	stmt := `SELECT id, title, content, created, expires, owner_id FROM snippets
+		WHERE expires > UTC_TIMESTAMP() AND owner_id = ? ORDER BY id DESC`
+
+	rows, err := m.DB.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snippets []Snippet
+
+	for rows.Next() {
+		var s Snippet
+
+		err = rows.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires, &s.OwnerID)
+		if err != nil {
+			return nil, err
+		}
+
+		snippets = append(snippets, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
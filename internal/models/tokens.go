@@ -0,0 +1,125 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Token scopes distinguish what a token row may be used for, so a
+// password-reset token can't be replayed to verify an email address, and an
+// API bearer token can't be replayed against either of those flows.
+const (
+	ScopeEmailVerification = "email-verification"
+	ScopePasswordReset     = "password-reset"
+	ScopeAuthentication    = "authentication"
+)
+
+// Token is the plaintext token handed to a user (in a verification link,
+// password-reset link, or API response) together with the data stored
+// alongside its hash.
+type Token struct {
+	Plaintext string
+	Hash      [32]byte
+	UserID    int
+	Scope     string
+	Expiry    time.Time
+}
+
+// TokenModel wraps a database connection pool for one of the tables that
+// store hashed, scoped, expiring tokens. user_tokens and auth_tokens share
+// an identical shape; only the table name and token length differ, so both
+// NewTokenModel and NewAuthTokenModel return a TokenModel parameterized
+// over those.
+type TokenModel struct {
+	DB *sql.DB
+
+	table      string
+	tokenBytes int
+}
+
+// NewTokenModel returns a TokenModel backed by db's user_tokens table, used
+// for email-verification and password-reset links.
+func NewTokenModel(db *sql.DB) *TokenModel {
+	return &TokenModel{DB: db, table: "user_tokens", tokenBytes: 16}
+}
+
+// NewAuthTokenModel returns a TokenModel backed by db's auth_tokens table,
+// used for API bearer tokens.
+func NewAuthTokenModel(db *sql.DB) *TokenModel {
+	return &TokenModel{DB: db, table: "auth_tokens", tokenBytes: 32}
+}
+
+// generateToken creates a new single-use Token for userID, valid for ttl and
+// restricted to scope. The plaintext value is never stored; only its SHA-256
+// hash is persisted by New.
+func generateToken(userID int, ttl time.Duration, scope string, tokenBytes int) (*Token, error) {
+	randomBytes := make([]byte, tokenBytes)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, err
+	}
+
+	token := &Token{
+		Plaintext: base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes),
+		UserID:    userID,
+		Scope:     scope,
+		Expiry:    time.Now().Add(ttl),
+	}
+	token.Hash = sha256.Sum256([]byte(token.Plaintext))
+
+	return token, nil
+}
+
+// New generates and persists a token for userID, returning the plaintext
+// value that should be emailed or returned to the user.
+func (m *TokenModel) New(userID int, ttl time.Duration, scope string) (*Token, error) {
+	token, err := generateToken(userID, ttl, scope, m.tokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s (hash, user_id, scope, expiry) VALUES (?, ?, ?, ?)`, m.table)
+
+	_, err = m.DB.Exec(stmt, token.Hash[:], token.UserID, token.Scope, token.Expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// GetUserForToken looks up the user associated with an unexpired token of
+// the given scope, matching on the plaintext value's SHA-256 hash.
+func (m *TokenModel) GetUserForToken(scope, plaintext string) (User, error) {
+	hash := sha256.Sum256([]byte(plaintext))
+
+	stmt := fmt.Sprintf(`SELECT users.id, users.name, users.email, users.created, users.verified_at
+		FROM users
+		INNER JOIN %s ON %s.user_id = users.id
+		WHERE %s.hash = ? AND %s.scope = ? AND %s.expiry > UTC_TIMESTAMP()`, m.table, m.table, m.table, m.table, m.table)
+
+	var u User
+
+	err := m.DB.QueryRow(stmt, hash[:], scope).Scan(&u.ID, &u.Name, &u.Email, &u.Created, &u.VerifiedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, ErrNoRecord
+		}
+		return User{}, err
+	}
+
+	return u, nil
+}
+
+// DeleteAllForUser removes every token of the given scope belonging to
+// userID, so a used or superseded token can't be replayed.
+func (m *TokenModel) DeleteAllForUser(scope string, userID int) error {
+	stmt := fmt.Sprintf(`DELETE FROM %s WHERE scope = ? AND user_id = ?`, m.table)
+
+	_, err := m.DB.Exec(stmt, scope, userID)
+	return err
+}
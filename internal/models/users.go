@@ -0,0 +1,155 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User represents a single row of the users table.
+type User struct {
+	ID         int
+	Name       string
+	Email      string
+	Created    time.Time
+	VerifiedAt sql.NullTime
+}
+
+// UserModel wraps a database connection pool for the users table. BcryptCost
+// is the work factor passed to bcrypt.GenerateFromPassword on signup.
+type UserModel struct {
+	DB         *sql.DB
+	BcryptCost int
+}
+
+// NewUserModel returns a UserModel backed by db, hashing passwords at
+// bcryptCost. A bcryptCost of 0 falls back to bcrypt.DefaultCost.
+func NewUserModel(db *sql.DB, bcryptCost int) *UserModel {
+	if bcryptCost == 0 {
+		bcryptCost = bcrypt.DefaultCost
+	}
+	return &UserModel{DB: db, BcryptCost: bcryptCost}
+}
+
+// Insert adds a new, unverified user to the database, storing a bcrypt hash
+// of password rather than the password itself, and returns the new user's id.
+func (m *UserModel) Insert(name, email, password string) (int, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), m.BcryptCost)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := `INSERT INTO users (name, email, hashed_password, created)
+		VALUES (?, ?, ?, UTC_TIMESTAMP())`
+
+	result, err := m.DB.Exec(stmt, name, email, string(hashedPassword))
+	if err != nil {
+		var mySQLError *mysql.MySQLError
+		if errors.As(err, &mySQLError) {
+			if mySQLError.Number == 1062 && (mySQLError.Message == "" || strings.Contains(mySQLError.Message, "users_uc_email")) {
+				return 0, ErrDuplicateEmail
+			}
+		}
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// GetByEmail returns the user with the given email address.
+func (m *UserModel) GetByEmail(email string) (User, error) {
+	var u User
+
+	stmt := `SELECT id, name, email, created, verified_at FROM users WHERE email = ?`
+
+	err := m.DB.QueryRow(stmt, email).Scan(&u.ID, &u.Name, &u.Email, &u.Created, &u.VerifiedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, ErrNoRecord
+		}
+		return User{}, err
+	}
+
+	return u, nil
+}
+
+// Authenticate verifies that a user exists with the given email and password,
+// returning their id if so.
+func (m *UserModel) Authenticate(email, password string) (int, error) {
+	var id int
+	var hashedPassword []byte
+
+	stmt := `SELECT id, hashed_password FROM users WHERE email = ?`
+
+	err := m.DB.QueryRow(stmt, email).Scan(&id, &hashedPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidCredentials
+		}
+		return 0, err
+	}
+
+	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return 0, ErrInvalidCredentials
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// IsVerified reports whether the user with the given id has confirmed their
+// email address.
+func (m *UserModel) IsVerified(id int) (bool, error) {
+	var verifiedAt sql.NullTime
+
+	err := m.DB.QueryRow(`SELECT verified_at FROM users WHERE id = ?`, id).Scan(&verifiedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrNoRecord
+		}
+		return false, err
+	}
+
+	return verifiedAt.Valid, nil
+}
+
+// Verify marks the user with the given id as having confirmed their email
+// address.
+func (m *UserModel) Verify(id int) error {
+	_, err := m.DB.Exec(`UPDATE users SET verified_at = UTC_TIMESTAMP() WHERE id = ?`, id)
+	return err
+}
+
+// UpdatePassword replaces the stored password hash for a user, re-hashing
+// newPassword with bcrypt.
+func (m *UserModel) UpdatePassword(id int, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), m.BcryptCost)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.DB.Exec(`UPDATE users SET hashed_password = ? WHERE id = ?`, string(hashedPassword), id)
+	return err
+}
+
+// Exists reports whether a user with the given id exists.
+func (m *UserModel) Exists(id int) (bool, error) {
+	var exists bool
+
+	stmt := `SELECT EXISTS(SELECT true FROM users WHERE id = ?)`
+
+	err := m.DB.QueryRow(stmt, id).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,25 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Middleware rejects requests with 429 once limiter.Allow(keyFunc(r)) says
+// no, setting Retry-After so well-behaved clients back off.
+func Middleware(limiter *Limiter, keyFunc func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, retryAfter := limiter.Allow(keyFunc(r))
+			if !ok {
+				if retryAfter > 0 {
+					w.Header().Set("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+				}
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
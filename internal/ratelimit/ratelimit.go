@@ -0,0 +1,97 @@
+// Package ratelimit implements a per-key token-bucket rate limiter, used to
+// throttle anonymous and authenticated requests separately.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter hands out a *rate.Limiter per key (e.g. "ip:1.2.3.4" or
+// "user:42"), evicting buckets that have been idle for longer than the
+// idleAfter duration passed to StartEvictor.
+type Limiter struct {
+	rps   rate.Limit
+	burst int
+	// now stands in for time.Now, so tests can advance idle-eviction time
+	// deterministically instead of sleeping. Unexported: only the package's
+	// own tests construct a Limiter with it overridden.
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New returns a Limiter allowing rps requests per second per key, with
+// bursts up to burst.
+func New(rps float64, burst int) *Limiter {
+	return &Limiter{
+		rps:     rate.Limit(rps),
+		burst:   burst,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow reports whether a request keyed by key is permitted right now. When
+// it isn't, retryAfter estimates how long the caller should wait.
+func (l *Limiter) Allow(key string) (ok bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{limiter: rate.NewLimiter(l.rps, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = l.now()
+	limiter := b.limiter
+	l.mu.Unlock()
+
+	reservation := limiter.Reserve()
+	if !reservation.OK() {
+		return false, 0
+	}
+
+	delay := reservation.Delay()
+	if delay > 0 {
+		reservation.Cancel()
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// StartEvictor removes buckets that have been idle for longer than idleAfter
+// every interval, until done is closed. It is meant to be run in its own
+// goroutine.
+func (l *Limiter) StartEvictor(done <-chan struct{}, interval, idleAfter time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.evictIdle(idleAfter)
+		case <-done:
+			return
+		}
+	}
+}
+
+func (l *Limiter) evictIdle(idleAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := l.now().Add(-idleAfter)
+	for key, b := range l.buckets {
+		if b.lastSeen.Before(cutoff) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowRespectsBurst(t *testing.T) {
+	l := New(1, 3)
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("ip:1.2.3.4")
+		if !ok {
+			t.Fatalf("request %d: want allowed within burst, got denied", i)
+		}
+	}
+
+	ok, retryAfter := l.Allow("ip:1.2.3.4")
+	if ok {
+		t.Fatal("want denied once burst is exhausted, got allowed")
+	}
+	if retryAfter <= 0 {
+		t.Fatalf("want a positive retryAfter once denied, got %v", retryAfter)
+	}
+}
+
+func TestAllowKeysBucketsIndependently(t *testing.T) {
+	l := New(1, 1)
+
+	if ok, _ := l.Allow("ip:1.2.3.4"); !ok {
+		t.Fatal("want first key's first request allowed")
+	}
+	if ok, _ := l.Allow("ip:1.2.3.4"); ok {
+		t.Fatal("want first key's second request denied")
+	}
+	if ok, _ := l.Allow("ip:5.6.7.8"); !ok {
+		t.Fatal("want a different key's bucket to be independent and allowed")
+	}
+}
+
+func TestEvictIdleRemovesOnlyStaleBuckets(t *testing.T) {
+	l := New(1, 1)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	l.Allow("ip:stale")
+	now = now.Add(2 * time.Minute)
+	l.Allow("ip:fresh")
+
+	l.evictIdle(time.Minute)
+
+	l.mu.Lock()
+	_, staleExists := l.buckets["ip:stale"]
+	_, freshExists := l.buckets["ip:fresh"]
+	l.mu.Unlock()
+
+	if staleExists {
+		t.Error("want bucket idle for longer than idleAfter to be evicted")
+	}
+	if !freshExists {
+		t.Error("want recently-seen bucket to survive eviction")
+	}
+}
@@ -0,0 +1,83 @@
+// Package reaper periodically deletes expired snippets in the background.
+package reaper
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+// Reaper deletes expired snippets on a timer and records the outcome of its
+// most recent run so it can be reported (e.g. over /debug/reaper).
+type Reaper struct {
+	db       *sql.DB
+	interval time.Duration
+	errorLog *log.Logger
+
+	mu          sync.Mutex
+	lastRun     time.Time
+	rowsDeleted int64
+}
+
+// New returns a Reaper that will delete expired snippets every interval once
+// Run is called.
+func New(db *sql.DB, interval time.Duration, errorLog *log.Logger) *Reaper {
+	return &Reaper{
+		db:       db,
+		interval: interval,
+		errorLog: errorLog,
+	}
+}
+
+// Run ticks every interval, deleting expired snippets, until ctx is
+// cancelled. It is meant to be called in its own goroutine.
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.reapOnce(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Reaper) reapOnce(ctx context.Context) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM snippets WHERE expires < UTC_TIMESTAMP()`)
+	if err != nil {
+		r.errorLog.Printf("reaper: %v", err)
+		return
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		r.errorLog.Printf("reaper: %v", err)
+		return
+	}
+
+	r.mu.Lock()
+	r.lastRun = time.Now()
+	r.rowsDeleted = rows
+	r.mu.Unlock()
+}
+
+// Stats is a snapshot of the reaper's most recent run.
+type Stats struct {
+	LastRun     time.Time
+	RowsDeleted int64
+}
+
+// Stats returns the outcome of the most recent successful run, or the zero
+// value if no run has succeeded yet. A run that fails is logged and leaves
+// the previous values in place.
+func (r *Reaper) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return Stats{LastRun: r.lastRun, RowsDeleted: r.rowsDeleted}
+}
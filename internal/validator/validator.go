@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// EmailRX is a regular expression pattern matching a valid email address,
+// lifted from https://html.spec.whatwg.org/#valid-e-mail-address.
+var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+
+// Validator holds validation error messages for form fields and for the
+// form as a whole.
+type Validator struct {
+	NonFieldErrors []string
+	FieldErrors    map[string]string
+}
+
+// Valid reports whether no validation errors have been recorded.
+func (v *Validator) Valid() bool {
+	return len(v.NonFieldErrors) == 0 && len(v.FieldErrors) == 0
+}
+
+// AddFieldError records an error message for a given field, unless one has
+// already been recorded for it.
+func (v *Validator) AddFieldError(key, message string) {
+	if v.FieldErrors == nil {
+		v.FieldErrors = make(map[string]string)
+	}
+
+	if _, exists := v.FieldErrors[key]; !exists {
+		v.FieldErrors[key] = message
+	}
+}
+
+// AddNonFieldError records an error message that isn't tied to a specific field.
+func (v *Validator) AddNonFieldError(message string) {
+	v.NonFieldErrors = append(v.NonFieldErrors, message)
+}
+
+// CheckField records an error message for a field if ok is false.
+func (v *Validator) CheckField(ok bool, key, message string) {
+	if !ok {
+		v.AddFieldError(key, message)
+	}
+}
+
+// NotBlank reports whether value contains at least one non-whitespace character.
+func NotBlank(value string) bool {
+	return strings.TrimSpace(value) != ""
+}
+
+// MaxRunes reports whether value contains no more than n runes.
+func MaxRunes(value string, n int) bool {
+	return utf8.RuneCountInString(value) <= n
+}
+
+// MinRunes reports whether value contains at least n runes.
+func MinRunes(value string, n int) bool {
+	return utf8.RuneCountInString(value) >= n
+}
+
+// AllowedValue reports whether value is one of the permitted values.
+func AllowedValue[T comparable](value T, permitted ...T) bool {
+	return slices.Contains(permitted, value)
+}
+
+// Matches reports whether value matches rx.
+func Matches(value string, rx *regexp.Regexp) bool {
+	return rx.MatchString(value)
+}